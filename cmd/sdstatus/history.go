@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/freedomofpress/sdstatus/pkg/directory"
+	"github.com/freedomofpress/sdstatus/pkg/history"
+)
+
+// historyCommand implements `sdstatus history <slug>`, printing every
+// stored scan result for one instance in chronological order.
+func historyCommand() cli.Command {
+	var format string
+	return cli.Command{
+		Name:      "history",
+		Usage:     "Print the stored scan history for one instance",
+		ArgsUsage: "<slug>",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:        "format",
+				Usage:       "Print history in `FORMAT`: csv, json",
+				Value:       "csv",
+				Destination: &format,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			slug := c.Args().First()
+			if slug == "" {
+				return fmt.Errorf("usage: sdstatus history <slug>")
+			}
+
+			historyDir := c.GlobalString("history-dir")
+			if historyDir == "" {
+				return fmt.Errorf("--history-dir must be set to look up history")
+			}
+
+			records, err := history.NewStore(historyDir).History(slug)
+			if err != nil {
+				return err
+			}
+			if len(records) == 0 {
+				return fmt.Errorf("no history recorded for %q", slug)
+			}
+
+			switch format {
+			case "csv":
+				return writeHistoryCSV(os.Stdout, records)
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(records)
+			default:
+				return fmt.Errorf("unknown format %q", format)
+			}
+		},
+	}
+}
+
+func writeHistoryCSV(w *os.File, records []history.Record) error {
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+	for _, r := range records {
+		record := []string{
+			r.Timestamp.Format(time.RFC3339),
+			fmt.Sprintf("%t", r.Instance.Available),
+			r.Instance.Metadata.Version,
+			r.Instance.Error,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	return cw.Error()
+}
+
+// diffCommand implements `sdstatus diff`, comparing a fresh scan to the
+// most recently stored snapshot of each instance and exiting non-zero if
+// anything notable changed, so it's easy to wire into cron for alerting.
+func diffCommand() cli.Command {
+	return cli.Command{
+		Name:  "diff",
+		Usage: "Scan and compare against the last recorded snapshot, exiting non-zero on regressions",
+		Action: func(c *cli.Context) error {
+			historyDir := c.GlobalString("history-dir")
+			if historyDir == "" {
+				return fmt.Errorf("--history-dir must be set to diff against")
+			}
+
+			connectTimeout := time.Duration(c.GlobalInt("connect-timeout")) * time.Second
+			readTimeout := time.Duration(c.GlobalInt("read-timeout")) * time.Second
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			start := time.Now()
+			instances, err := scan(ctx, connectTimeout, readTimeout)
+			if err != nil {
+				return err
+			}
+			duration := time.Since(start)
+
+			store := history.NewStore(historyDir)
+			changed := false
+			for _, i := range instances {
+				previous, ok, err := store.Latest(i)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
+				if diff := diffInstance(previous.Instance, i); diff != "" {
+					fmt.Fprintln(os.Stdout, diff)
+					changed = true
+				}
+			}
+
+			if err := recordHistory(historyDir, instances, duration); err != nil {
+				return err
+			}
+
+			if changed {
+				return cli.NewExitError("instances changed since last scan", 1)
+			}
+			return nil
+		},
+	}
+}
+
+// diffInstance describes what notably changed between a previous and
+// current scan of the same instance, or "" if nothing did.
+func diffInstance(previous, current directory.Instance) string {
+	switch {
+	case previous.Available && !current.Available:
+		return fmt.Sprintf("%s: became unavailable (%s)", current.Slug, current.Error)
+	case previous.Metadata.Version != current.Metadata.Version:
+		return fmt.Sprintf("%s: version changed %q -> %q", current.Slug, previous.Metadata.Version, current.Metadata.Version)
+	case previous.Metadata.Fingerprint != current.Metadata.Fingerprint:
+		return fmt.Sprintf("%s: fingerprint changed %q -> %q", current.Slug, previous.Metadata.Fingerprint, current.Metadata.Fingerprint)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/freedomofpress/sdstatus/pkg/directory"
+	"github.com/freedomofpress/sdstatus/pkg/history"
+	"github.com/freedomofpress/sdstatus/pkg/output"
+	"github.com/freedomofpress/sdstatus/pkg/scanner"
+)
+
+const (
+	// proxyAddr points to local SOCKS proxy from Tor
+	proxyAddr = "127.0.0.1:9050"
+
+	// defaultConnectTimeout bounds how long we wait for the SOCKS dial to
+	// a hidden service to complete.
+	defaultConnectTimeout = 10 * time.Second
+	// defaultReadTimeout bounds the full request/response round trip,
+	// including the SOCKS dial and reading the metadata body.
+	defaultReadTimeout = 30 * time.Second
+)
+
+// scan fetches the current directory listing and checks every instance
+// over Tor, returning the scanned results.
+func scan(ctx context.Context, connectTimeout, readTimeout time.Duration) ([]directory.Instance, error) {
+	instances, err := directory.NewClient().GetDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := scanner.NewTorScanner(proxyAddr, connectTimeout, readTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []directory.Instance
+	for r := range scanner.ScanAll(ctx, s, instances) {
+		results = append(results, r.Instance)
+	}
+	return results, nil
+}
+
+// recordHistory persists every result to the history store at historyDir,
+// all under the same timestamp since they came from one scan. It's a
+// no-op if historyDir is empty.
+func recordHistory(historyDir string, results []directory.Instance, duration time.Duration) error {
+	if historyDir == "" {
+		return nil
+	}
+	store := history.NewStore(historyDir)
+	now := time.Now()
+	for _, i := range results {
+		if err := store.Record(i, duration, now); err != nil {
+			return fmt.Errorf("recording history for %s: %w", i.Slug, err)
+		}
+	}
+	return nil
+}
+
+func createApp() *cli.App {
+	app := cli.NewApp()
+	var format string
+	var connectTimeout int
+	var readTimeout int
+	var historyDir string
+	app.EnableBashCompletion = true
+	app.Name = "sdstatus"
+	app.Version = "0.1.0"
+	app.Usage = "To scan SecureDrop instances"
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:        "format",
+			Usage:       "Output scan results in `FORMAT`: csv, json, jsonl, pp, table",
+			Value:       "csv",
+			Destination: &format,
+		},
+		cli.IntFlag{
+			Name:        "connect-timeout",
+			Usage:       "Abort a SOCKS dial after `SECONDS` without a connection",
+			Value:       int(defaultConnectTimeout / time.Second),
+			Destination: &connectTimeout,
+		},
+		cli.IntFlag{
+			Name:        "read-timeout",
+			Usage:       "Abort a single instance's request/response after `SECONDS`",
+			Value:       int(defaultReadTimeout / time.Second),
+			Destination: &readTimeout,
+		},
+		cli.StringFlag{
+			Name:        "history-dir",
+			Usage:       "Persist every scan result under `DIR` for later `history`/`diff` lookups",
+			Destination: &historyDir,
+		},
+	}
+	app.Commands = []cli.Command{
+		serveCommand(),
+		historyCommand(),
+		diffCommand(),
+	}
+	app.Action = func(c *cli.Context) error {
+		writer, ok := output.Writers[format]
+		if !ok {
+			return fmt.Errorf("unknown format %q", format)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		start := time.Now()
+		instances, err := scan(ctx, time.Duration(connectTimeout)*time.Second, time.Duration(readTimeout)*time.Second)
+		if err != nil {
+			return err
+		}
+		if err := recordHistory(historyDir, instances, time.Since(start)); err != nil {
+			return err
+		}
+		return writer.Write(os.Stdout, instances)
+	}
+
+	return app
+}
+
+func main() {
+	app := createApp()
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
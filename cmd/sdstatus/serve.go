@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/urfave/cli"
+
+	"github.com/freedomofpress/sdstatus/pkg/directory"
+	"github.com/freedomofpress/sdstatus/pkg/history"
+	"github.com/freedomofpress/sdstatus/pkg/scanner"
+)
+
+var (
+	instanceAvailableGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sdstatus_instance_available",
+		Help: "Whether a SecureDrop instance answered its /metadata endpoint (1) or not (0).",
+	}, []string{"title", "url", "version"})
+
+	scanDurationGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "sdstatus_scan_duration_seconds",
+		Help: "Duration of the most recently completed directory scan, in seconds.",
+	})
+
+	instancesTotalGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sdstatus_instances_total",
+		Help: "Number of instances from the most recent scan, by availability.",
+	}, []string{"available"})
+)
+
+func init() {
+	prometheus.MustRegister(instanceAvailableGauge, scanDurationGauge, instancesTotalGauge)
+}
+
+// scanState holds the most recent scan results behind an RWMutex so the
+// HTTP handlers can serve a consistent snapshot while a new scan runs
+// concurrently in the background.
+type scanState struct {
+	mu        sync.RWMutex
+	instances []directory.Instance
+	lastScan  time.Time
+}
+
+func (s *scanState) set(instances []directory.Instance) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.instances = instances
+	s.lastScan = time.Now()
+}
+
+func (s *scanState) all() []directory.Instance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	instances := make([]directory.Instance, len(s.instances))
+	copy(instances, s.instances)
+	return instances
+}
+
+func (s *scanState) bySlug(slug string) (directory.Instance, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, i := range s.instances {
+		if i.Slug == slug {
+			return i, true
+		}
+	}
+	return directory.Instance{}, false
+}
+
+// recordMetrics refreshes the Prometheus gauges from a completed scan. The
+// gauge vector is reset first so instances that disappear from the
+// directory don't leave stale series behind.
+func recordMetrics(instances []directory.Instance, duration time.Duration) {
+	instanceAvailableGauge.Reset()
+	var available, unavailable float64
+	for _, i := range instances {
+		value := 0.0
+		if i.Available {
+			value = 1.0
+			available++
+		} else {
+			unavailable++
+		}
+		instanceAvailableGauge.WithLabelValues(i.Title, i.OnionAddress, i.Metadata.Version).Set(value)
+	}
+	instancesTotalGauge.WithLabelValues("true").Set(available)
+	instancesTotalGauge.WithLabelValues("false").Set(unavailable)
+	scanDurationGauge.Set(duration.Seconds())
+}
+
+// scanOnce runs a single directory scan to completion, then publishes the
+// results to state and to the Prometheus gauges. If historyDir is set,
+// every result is also persisted for later `history`/`diff` lookups.
+func scanOnce(ctx context.Context, state *scanState, connectTimeout, readTimeout time.Duration, historyDir string) {
+	start := time.Now()
+
+	instances, err := directory.NewClient().GetDirectory()
+	if err != nil {
+		log.Printf("scan failed: fetching directory: %s", err)
+		return
+	}
+
+	s, err := scanner.NewTorScanner(proxyAddr, connectTimeout, readTimeout)
+	if err != nil {
+		log.Printf("scan failed: %s", err)
+		return
+	}
+
+	var results []directory.Instance
+	for r := range scanner.ScanAll(ctx, s, instances) {
+		results = append(results, r.Instance)
+	}
+
+	duration := time.Since(start)
+	state.set(results)
+	recordMetrics(results, duration)
+	if err := recordHistory(historyDir, results, duration); err != nil {
+		log.Printf("recording history failed: %s", err)
+	}
+	log.Printf("scan complete: %d instances in %s", len(results), duration)
+}
+
+func instancesHandler(state *scanState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(state.all())
+	}
+}
+
+func instanceHandler(state *scanState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		slug := strings.TrimPrefix(r.URL.Path, "/instances/")
+		instance, ok := state.bySlug(slug)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(instance)
+	}
+}
+
+func scanHandler(ctx context.Context, state *scanState, connectTimeout, readTimeout time.Duration, historyDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		// Run out-of-cycle scans in the background so the request returns
+		// immediately; the result shows up on the next /instances poll.
+		go scanOnce(ctx, state, connectTimeout, readTimeout, historyDir)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// runJanitor prunes history records older than retention on a timer,
+// until ctx is done. It's a no-op if historyDir is empty.
+func runJanitor(ctx context.Context, historyDir string, retention time.Duration) {
+	if historyDir == "" {
+		return
+	}
+	store := history.NewStore(historyDir)
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := store.Prune(retention); err != nil {
+				log.Printf("pruning history failed: %s", err)
+			}
+		}
+	}
+}
+
+// serveCommand implements `sdstatus serve`, turning the one-shot scanner
+// into a resident service that re-scans the directory on a timer and
+// exposes the latest results over HTTP.
+func serveCommand() cli.Command {
+	var interval int
+	var addr string
+	var historyRetention int
+	return cli.Command{
+		Name:  "serve",
+		Usage: "Run as a resident service with an HTTP status API and Prometheus metrics",
+		Flags: []cli.Flag{
+			cli.IntFlag{
+				Name:        "interval",
+				Usage:       "Re-scan the SecureDrop directory every `SECONDS`",
+				Value:       300,
+				Destination: &interval,
+			},
+			cli.StringFlag{
+				Name:        "addr",
+				Usage:       "Listen on `ADDR` for the HTTP status API",
+				Value:       ":8080",
+				Destination: &addr,
+			},
+			cli.IntFlag{
+				Name:        "history-retention",
+				Usage:       "Prune history records older than `SECONDS` (requires --history-dir)",
+				Value:       int(30 * 24 * time.Hour / time.Second),
+				Destination: &historyRetention,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			connectTimeout := time.Duration(c.GlobalInt("connect-timeout")) * time.Second
+			readTimeout := time.Duration(c.GlobalInt("read-timeout")) * time.Second
+			historyDir := c.GlobalString("history-dir")
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			state := &scanState{}
+			scanOnce(ctx, state, connectTimeout, readTimeout, historyDir)
+
+			go func() {
+				ticker := time.NewTicker(time.Duration(interval) * time.Second)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						scanOnce(ctx, state, connectTimeout, readTimeout, historyDir)
+					}
+				}
+			}()
+
+			go runJanitor(ctx, historyDir, time.Duration(historyRetention)*time.Second)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/instances", instancesHandler(state))
+			mux.HandleFunc("/instances/", instanceHandler(state))
+			mux.HandleFunc("/scan", scanHandler(ctx, state, connectTimeout, readTimeout, historyDir))
+			mux.Handle("/metrics", promhttp.Handler())
+
+			server := &http.Server{Addr: addr, Handler: mux}
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				server.Shutdown(shutdownCtx)
+			}()
+
+			log.Printf("sdstatus serve listening on %s, rescanning every %ds", addr, interval)
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,85 @@
+// Package directory fetches and parses the published list of SecureDrop
+// instances from securedrop.org.
+package directory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// DefaultBaseURL is the production SecureDrop directory API.
+const DefaultBaseURL = "https://securedrop.org/api/v1/directory/"
+
+// OrganizationLogo describes the logo image attached to a directory listing.
+type OrganizationLogo struct {
+	Height int    `json:"height"`
+	Width  int    `json:"width"`
+	URL    string `json:"url"`
+}
+
+// Metadata is the information obtained from a given SecureDrop instance's
+// own /metadata endpoint, a JSON API with platform info.
+type Metadata struct {
+	Version     string `json:"sd_version"`
+	Platform    string `json:"server_os"`
+	Fingerprint string `json:"gpg_fpr"`
+	V2SourceURL string `json:"v2_source_url"`
+	V3SourceURL string `json:"v3_source_url"`
+}
+
+// Instance is the canonical representation of a SecureDrop instance: the
+// directory listing fields, merged with the Metadata and availability
+// fields that a scanner fills in once the instance has been checked.
+type Instance struct {
+	Title                   string           `json:"title"`
+	Slug                    string           `json:"slug"`
+	DirectoryURL            string           `json:"directory_url"`
+	FirstPublishedAt        time.Time        `json:"first_published_at"`
+	LandingPageURL          string           `json:"landing_page_url"`
+	OnionAddress            string           `json:"onion_address"`
+	OrganizationLogo        OrganizationLogo `json:"organization_logo"`
+	OrganizationDescription string           `json:"organization_description"`
+	Languages               []string         `json:"languages"`
+	Topics                  []string         `json:"topics"`
+	Countries               []string         `json:"countries"`
+
+	Metadata  Metadata `json:"metadata"`
+	Available bool     `json:"available"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Client fetches the directory listing from a configurable base URL, so
+// tests can point it at an httptest.Server instead of the production API.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a Client for the production directory API.
+func NewClient() *Client {
+	return &Client{BaseURL: DefaultBaseURL, HTTPClient: http.DefaultClient}
+}
+
+// GetDirectory fetches and parses the full list of published SecureDrop
+// instances.
+func (c *Client) GetDirectory() ([]Instance, error) {
+	resp, err := c.HTTPClient.Get(c.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching directory: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading directory response: %w", err)
+	}
+
+	var instances []Instance
+	if err := json.Unmarshal(body, &instances); err != nil {
+		return nil, fmt.Errorf("parsing directory response: %w", err)
+	}
+	return instances, nil
+}
@@ -0,0 +1,56 @@
+package directory
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetDirectory(t *testing.T) {
+	cases := []struct {
+		name    string
+		body    string
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name:    "single instance",
+			body:    `[{"title":"Test Leaks","slug":"test-leaks","onion_address":"abc.onion"}]`,
+			wantLen: 1,
+		},
+		{
+			name:    "empty directory",
+			body:    `[]`,
+			wantLen: 0,
+		},
+		{
+			name:    "bad json",
+			body:    `not json`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			client := &Client{BaseURL: server.URL, HTTPClient: server.Client()}
+			instances, err := client.GetDirectory()
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(instances) != tc.wantLen {
+				t.Fatalf("got %d instances, want %d", len(instances), tc.wantLen)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+// Package history persists scan results to disk so operators can inspect
+// availability and version trends over time without running an external
+// time-series database. It models syncthing's crash receiver diskstore:
+// one directory per instance, one file per record, named by timestamp.
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/freedomofpress/sdstatus/pkg/directory"
+)
+
+// Record is a single stored scan result for one instance.
+type Record struct {
+	Timestamp time.Time          `json:"timestamp"`
+	Instance  directory.Instance `json:"instance"`
+	Duration  time.Duration      `json:"duration"`
+}
+
+// Store persists Records under a base directory, one subdirectory per
+// instance keyed by a hash of its onion address.
+type Store struct {
+	BaseDir string
+}
+
+// NewStore returns a Store rooted at baseDir. The directory is created on
+// first write and need not exist yet.
+func NewStore(baseDir string) *Store {
+	return &Store{BaseDir: baseDir}
+}
+
+// Key derives the stable, filesystem-safe directory name for an onion
+// address. It's a hash rather than the raw address so the store doesn't
+// depend on the address being a valid path component.
+func Key(onionAddress string) string {
+	sum := sha256.Sum256([]byte(onionAddress))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Record appends a new record for instance to the store.
+func (s *Store) Record(instance directory.Instance, duration time.Duration, timestamp time.Time) error {
+	dir := filepath.Join(s.BaseDir, Key(instance.OnionAddress))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating history dir: %w", err)
+	}
+
+	record := Record{Timestamp: timestamp, Instance: instance, Duration: duration}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshaling record: %w", err)
+	}
+
+	// Nanosecond precision rather than plain RFC3339 so two scans within the
+	// same second don't clobber each other's record. The fraction is always
+	// nine digits wide (unlike RFC3339Nano, which trims trailing zeros) so
+	// filenames sort lexically in chronological order.
+	path := filepath.Join(dir, timestamp.UTC().Format("2006-01-02T15:04:05.000000000Z07:00")+".json")
+	if err := ioutil.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing record: %w", err)
+	}
+	return nil
+}
+
+// History returns every record stored for the instance with the given
+// slug, in chronological order. It scans every instance directory
+// looking for a match, since the on-disk key is a hash rather than the
+// slug itself.
+func (s *Store) History(slug string) ([]Record, error) {
+	entries, err := ioutil.ReadDir(s.BaseDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading history dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		records, err := readRecords(filepath.Join(s.BaseDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if len(records) > 0 && records[len(records)-1].Instance.Slug == slug {
+			return records, nil
+		}
+	}
+	return nil, nil
+}
+
+// Latest returns the most recently recorded result for instance, if any.
+func (s *Store) Latest(instance directory.Instance) (Record, bool, error) {
+	records, err := readRecords(filepath.Join(s.BaseDir, Key(instance.OnionAddress)))
+	if err != nil {
+		return Record{}, false, err
+	}
+	if len(records) == 0 {
+		return Record{}, false, nil
+	}
+	return records[len(records)-1], true, nil
+}
+
+// Prune deletes every record older than retention, across all instances.
+// It's meant to run periodically as a background janitor.
+func (s *Store) Prune(retention time.Duration) error {
+	entries, err := ioutil.ReadDir(s.BaseDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading history dir: %w", err)
+	}
+
+	cutoff := time.Now().Add(-retention)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(s.BaseDir, entry.Name())
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("reading instance history: %w", err)
+		}
+		for _, f := range files {
+			ts, err := time.Parse(time.RFC3339, strings.TrimSuffix(f.Name(), ".json"))
+			if err != nil {
+				continue
+			}
+			if ts.Before(cutoff) {
+				if err := os.Remove(filepath.Join(dir, f.Name())); err != nil {
+					return fmt.Errorf("pruning record: %w", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readRecords loads every record file in dir, in chronological order.
+// Filenames sort lexically the same as chronologically because they're
+// fixed-width UTC RFC3339 timestamps.
+func readRecords(dir string) ([]Record, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading instance history: %w", err)
+	}
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		if !f.IsDir() {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+
+	records := make([]Record, 0, len(names))
+	for _, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("reading record %s: %w", name, err)
+		}
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("parsing record %s: %w", name, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,126 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/freedomofpress/sdstatus/pkg/directory"
+)
+
+func TestStoreRecordAndHistory(t *testing.T) {
+	store := NewStore(t.TempDir())
+	instance := directory.Instance{Slug: "test-leaks", OnionAddress: "abc.onion"}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, version := range []string{"2.7.0", "2.7.1", "2.8.0"} {
+		instance.Metadata.Version = version
+		if err := store.Record(instance, time.Second, base.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	records, err := store.History("test-leaks")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+	if records[0].Instance.Metadata.Version != "2.7.0" || records[2].Instance.Metadata.Version != "2.8.0" {
+		t.Fatalf("records not in chronological order: %+v", records)
+	}
+
+	latest, ok, err := store.Latest(instance)
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if !ok {
+		t.Fatalf("expected a latest record")
+	}
+	if latest.Instance.Metadata.Version != "2.8.0" {
+		t.Fatalf("got latest version %q, want 2.8.0", latest.Instance.Metadata.Version)
+	}
+}
+
+func TestStoreRecordSameSecondDoesNotCollide(t *testing.T) {
+	store := NewStore(t.TempDir())
+	instance := directory.Instance{Slug: "test-leaks", OnionAddress: "abc.onion"}
+
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, version := range []string{"2.7.0", "2.7.1"} {
+		instance.Metadata.Version = version
+		if err := store.Record(instance, time.Second, ts.Add(time.Duration(i)*time.Nanosecond)); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	records, err := store.History("test-leaks")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2 (same-second records should not overwrite each other)", len(records))
+	}
+}
+
+func TestStoreHistoryMatchesRenamedSlug(t *testing.T) {
+	store := NewStore(t.TempDir())
+	instance := directory.Instance{Slug: "old-slug", OnionAddress: "abc.onion"}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := store.Record(instance, time.Second, base); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	instance.Slug = "new-slug"
+	if err := store.Record(instance, time.Second, base.Add(time.Hour)); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	records, err := store.History("new-slug")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2 (lookup by current slug should find records stored under an earlier slug)", len(records))
+	}
+}
+
+func TestStoreHistoryUnknownSlug(t *testing.T) {
+	store := NewStore(t.TempDir())
+	records, err := store.History("nonexistent")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if records != nil {
+		t.Fatalf("got %v, want nil", records)
+	}
+}
+
+func TestStorePrune(t *testing.T) {
+	store := NewStore(t.TempDir())
+	instance := directory.Instance{Slug: "test-leaks", OnionAddress: "abc.onion"}
+
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now().Add(-1 * time.Hour)
+	if err := store.Record(instance, time.Second, old); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := store.Record(instance, time.Second, recent); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	if err := store.Prune(24 * time.Hour); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+
+	records, err := store.History("test-leaks")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records after prune, want 1", len(records))
+	}
+	if !records[0].Timestamp.Equal(recent) {
+		t.Fatalf("got timestamp %v, want %v", records[0].Timestamp, recent)
+	}
+}
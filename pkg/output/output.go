@@ -0,0 +1,95 @@
+// Package output renders scanned SecureDrop instances in the formats the
+// sdstatus CLI supports.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/k0kubun/pp"
+
+	"github.com/freedomofpress/sdstatus/pkg/directory"
+)
+
+// Writer renders a batch of scanned instances to w.
+type Writer interface {
+	Write(w io.Writer, instances []directory.Instance) error
+}
+
+// Writers maps a --format flag value to the Writer that implements it.
+var Writers = map[string]Writer{
+	"csv":   csvWriter{},
+	"json":  jsonWriter{},
+	"jsonl": jsonlWriter{},
+	"pp":    ppWriter{},
+	"table": tableWriter{},
+}
+
+type csvWriter struct{}
+
+func (csvWriter) Write(w io.Writer, instances []directory.Instance) error {
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+	for _, i := range instances {
+		record := []string{
+			fmt.Sprintf("%t", i.Available),
+			i.Metadata.Version,
+			i.Title,
+			i.OnionAddress,
+			i.Error,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	return cw.Error()
+}
+
+// jsonWriter writes the whole batch as a single pretty-printed JSON array.
+type jsonWriter struct{}
+
+func (jsonWriter) Write(w io.Writer, instances []directory.Instance) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(instances)
+}
+
+// jsonlWriter writes one compact JSON object per instance per line, for
+// streaming consumers that don't want to buffer the full batch.
+type jsonlWriter struct{}
+
+func (jsonlWriter) Write(w io.Writer, instances []directory.Instance) error {
+	enc := json.NewEncoder(w)
+	for _, i := range instances {
+		if err := enc.Encode(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ppWriter struct{}
+
+func (ppWriter) Write(w io.Writer, instances []directory.Instance) error {
+	for _, i := range instances {
+		if _, err := pp.Fprintln(w, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableWriter writes an aligned, human-readable table for terminal use.
+type tableWriter struct{}
+
+func (tableWriter) Write(w io.Writer, instances []directory.Instance) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "AVAILABLE\tVERSION\tTITLE\tONION ADDRESS\tERROR")
+	for _, i := range instances {
+		fmt.Fprintf(tw, "%t\t%s\t%s\t%s\t%s\n", i.Available, i.Metadata.Version, i.Title, i.OnionAddress, i.Error)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,54 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/freedomofpress/sdstatus/pkg/directory"
+)
+
+func TestWriters(t *testing.T) {
+	instances := []directory.Instance{
+		{
+			Title:        "Test Leaks",
+			OnionAddress: "abc.onion",
+			Available:    true,
+			Metadata:     directory.Metadata{Version: "2.8.0"},
+		},
+	}
+
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{format: "csv", want: "abc.onion"},
+		{format: "json", want: `"onion_address": "abc.onion"`},
+		{format: "jsonl", want: `"onion_address":"abc.onion"`},
+		{format: "table", want: "abc.onion"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			writer, ok := Writers[tc.format]
+			if !ok {
+				t.Fatalf("no writer registered for format %q", tc.format)
+			}
+			var buf bytes.Buffer
+			if err := writer.Write(&buf, instances); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(buf.String(), tc.want) {
+				t.Fatalf("output %q does not contain %q", buf.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestWritersRegistersKnownFormats(t *testing.T) {
+	for _, format := range []string{"csv", "json", "jsonl", "pp", "table"} {
+		if _, ok := Writers[format]; !ok {
+			t.Errorf("expected a writer registered for format %q", format)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package scanner checks the live availability and metadata of SecureDrop
+// instances, normally over a Tor SOCKS5 proxy.
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/proxy"
+
+	"github.com/freedomofpress/sdstatus/pkg/directory"
+)
+
+// Result is the outcome of scanning a single instance: the instance with
+// its Available/Metadata/Error fields populated, plus how long the scan
+// of that instance took.
+type Result struct {
+	Instance directory.Instance
+	Duration time.Duration
+}
+
+// Scanner checks a single SecureDrop instance and reports the result.
+type Scanner interface {
+	Scan(ctx context.Context, instance directory.Instance) Result
+}
+
+// Dialer is the subset of proxy.Dialer that TorScanner depends on, so
+// tests can substitute a fake dialer instead of a real SOCKS5 proxy.
+type Dialer interface {
+	Dial(network, addr string) (net.Conn, error)
+}
+
+// TorScanner scans instances over a SOCKS5 proxy (normally a local Tor
+// daemon), with a bounded connect and read timeout per instance.
+type TorScanner struct {
+	HTTPClient     *http.Client
+	ConnectTimeout time.Duration
+	ReadTimeout    time.Duration
+}
+
+// NewTorScanner builds a TorScanner that dials through the SOCKS5 proxy at
+// proxyAddr (typically Tor's local listener, e.g. "127.0.0.1:9050").
+func NewTorScanner(proxyAddr string, connectTimeout, readTimeout time.Duration) (*TorScanner, error) {
+	dialer, err := proxy.SOCKS5("tcp", proxyAddr, nil, proxy.Direct)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to proxy: %w", err)
+	}
+	return NewTorScannerWithDialer(dialer, connectTimeout, readTimeout), nil
+}
+
+// NewTorScannerWithDialer builds a TorScanner around an already-constructed
+// Dialer, letting callers (including tests) supply a fake in place of a
+// real SOCKS5 proxy.
+func NewTorScannerWithDialer(dialer Dialer, connectTimeout, readTimeout time.Duration) *TorScanner {
+	cd := &contextDialer{dialer: dialer, connectTimeout: connectTimeout}
+	transport := &http.Transport{DialContext: cd.DialContext}
+	return &TorScanner{
+		HTTPClient:     &http.Client{Transport: transport},
+		ConnectTimeout: connectTimeout,
+		ReadTimeout:    readTimeout,
+	}
+}
+
+// Scan fetches instance's /metadata endpoint and returns a Result wrapping
+// a copy of instance with Available, Metadata and Error populated.
+// Failures are recorded on Error instead of aborting the caller's scan.
+func (s *TorScanner) Scan(ctx context.Context, instance directory.Instance) Result {
+	start := time.Now()
+	instance.Available = false
+
+	metadataURL := fmt.Sprintf("http://%s/metadata", instance.OnionAddress)
+
+	reqCtx, cancel := context.WithTimeout(ctx, s.ReadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequest("GET", metadataURL, nil)
+	if err != nil {
+		instance.Error = err.Error()
+		return Result{Instance: instance, Duration: time.Since(start)}
+	}
+	req = req.WithContext(reqCtx)
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		instance.Error = classifyError(err)
+		return Result{Instance: instance, Duration: time.Since(start)}
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		instance.Error = classifyError(err)
+		return Result{Instance: instance, Duration: time.Since(start)}
+	}
+
+	var metadata directory.Metadata
+	if err := json.Unmarshal(body, &metadata); err != nil {
+		instance.Error = "bad json"
+		return Result{Instance: instance, Duration: time.Since(start)}
+	}
+
+	instance.Metadata = metadata
+	instance.Available = true
+	instance.Error = ""
+	return Result{Instance: instance, Duration: time.Since(start)}
+}
+
+// classifyError maps a failed scan attempt to a short, stable reason code
+// so callers can distinguish "instance is down" from "we couldn't even
+// ask it".
+func classifyError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && errors.Is(opErr.Err, syscall.ECONNREFUSED) {
+		return "connect refused"
+	}
+	if strings.Contains(err.Error(), "socks connect") {
+		return "tor down"
+	}
+	return err.Error()
+}
+
+// contextDialer wraps a Dialer, which has no notion of contexts, so that a
+// scan-wide deadline (or SIGINT) can abort an in-flight SOCKS dial. It
+// follows the deadlineTimer pattern from netstack's gonet adapter: each
+// dial runs in its own goroutine and races against ctx.Done(), so a single
+// hung dial can't block the caller past connectTimeout.
+type contextDialer struct {
+	dialer         Dialer
+	connectTimeout time.Duration
+}
+
+func (d *contextDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.connectTimeout)
+	defer cancel()
+
+	connCh := make(chan net.Conn, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		conn, err := d.dialer.Dial(network, addr)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		connCh <- conn
+	}()
+
+	select {
+	case conn := <-connCh:
+		return conn, nil
+	case err := <-errCh:
+		return nil, err
+	case <-ctx.Done():
+		// The dial may still complete after we give up on it; drain it in
+		// the background so we don't leak the connection or the goroutine.
+		go func() {
+			select {
+			case conn := <-connCh:
+				conn.Close()
+			case <-errCh:
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// ScanAll scans every instance concurrently using s, streaming results on
+// the returned channel as they complete. The channel is closed once every
+// instance has been scanned.
+func ScanAll(ctx context.Context, s Scanner, instances []directory.Instance) <-chan Result {
+	ch := make(chan Result)
+	var wg sync.WaitGroup
+	for _, i := range instances {
+		wg.Add(1)
+		go func(i directory.Instance) {
+			defer wg.Done()
+			ch <- s.Scan(ctx, i)
+		}(i)
+	}
+	go func() {
+		wg.Wait()
+		close(ch)
+	}()
+	return ch
+}
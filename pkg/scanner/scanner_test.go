@@ -0,0 +1,79 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/freedomofpress/sdstatus/pkg/directory"
+)
+
+// fakeDialer returns a canned net.Conn (backed by net.Pipe) whose peer
+// writes a fixed HTTP response, so TorScanner.Scan can be exercised
+// without a real SOCKS proxy or network.
+type fakeDialer struct {
+	response string
+	err      error
+}
+
+func (d *fakeDialer) Dial(network, addr string) (net.Conn, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	client, server := net.Pipe()
+	go func() {
+		buf := make([]byte, 4096)
+		server.Read(buf)
+		server.Write([]byte(d.response))
+		server.Close()
+	}()
+	return client, nil
+}
+
+func TestTorScannerScan(t *testing.T) {
+	cases := []struct {
+		name          string
+		dialer        *fakeDialer
+		wantAvailable bool
+		wantVersion   string
+		wantError     string
+	}{
+		{
+			name: "available",
+			dialer: &fakeDialer{response: "HTTP/1.1 200 OK\r\nConnection: close\r\n\r\n" +
+				`{"sd_version":"2.8.0","server_os":"focal"}`},
+			wantAvailable: true,
+			wantVersion:   "2.8.0",
+		},
+		{
+			name:      "dial refused",
+			dialer:    &fakeDialer{err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}},
+			wantError: "connect refused",
+		},
+		{
+			name: "bad json",
+			dialer: &fakeDialer{response: "HTTP/1.1 200 OK\r\nConnection: close\r\n\r\n" +
+				`not json`},
+			wantError: "bad json",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewTorScannerWithDialer(tc.dialer, time.Second, time.Second)
+			result := s.Scan(context.Background(), directory.Instance{OnionAddress: "test.onion"})
+
+			if result.Instance.Available != tc.wantAvailable {
+				t.Fatalf("got available=%t, want %t (error=%q)", result.Instance.Available, tc.wantAvailable, result.Instance.Error)
+			}
+			if tc.wantVersion != "" && result.Instance.Metadata.Version != tc.wantVersion {
+				t.Fatalf("got version %q, want %q", result.Instance.Metadata.Version, tc.wantVersion)
+			}
+			if tc.wantError != "" && result.Instance.Error != tc.wantError {
+				t.Fatalf("got error %q, want %q", result.Instance.Error, tc.wantError)
+			}
+		})
+	}
+}